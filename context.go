@@ -0,0 +1,108 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	traceIDKey
+)
+
+// WithRequestID stashes id on ctx so *Ctx log calls downstream include it.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// WithTraceID stashes id on ctx so *Ctx log calls downstream include it.
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, traceIDKey, id)
+}
+
+// Field is a single key/value pair extracted from a context.Context by a
+// ContextExtractor.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// ContextExtractor pulls Fields out of a context.Context, for integrators
+// that carry their own identifiers (OpenTelemetry spans, gRPC metadata,
+// ...) instead of, or alongside, WithRequestID/WithTraceID.
+type ContextExtractor func(ctx context.Context) []Field
+
+// extractors are consulted, in registration order, by every *Ctx logging
+// call, after the built-in request/trace ID extraction.
+var extractors []ContextExtractor
+
+// RegisterContextExtractor adds extractor to the set consulted by every
+// *Ctx logging call. Intended to be called once at startup; it is not
+// safe to call concurrently with logging.
+func RegisterContextExtractor(extractor ContextExtractor) {
+	extractors = append(extractors, extractor)
+}
+
+// extractFields runs the built-in request/trace ID extraction followed by
+// every registered ContextExtractor.
+func extractFields(ctx context.Context) []Field {
+	var fields []Field
+	if id, ok := ctx.Value(requestIDKey).(string); ok {
+		fields = append(fields, Field{"rid", id})
+	}
+	if id, ok := ctx.Value(traceIDKey).(string); ok {
+		fields = append(fields, Field{"tid", id})
+	}
+	for _, e := range extractors {
+		fields = append(fields, e(ctx)...)
+	}
+	return fields
+}
+
+// ctxPrefix renders fields as a text-mode "[rid=... tid=...] " prefix, or
+// "" in FormatJSON, where the *Ctx functions instead attach fields as JSON
+// properties via fieldsToMap. Applying both would duplicate the data in
+// every JSON record. format describes a Logger's single primary rendering,
+// so this doesn't catch the same duplication into a JSON sink stacked
+// alongside a FormatText primary output via AppendJSONWriter; that mixed
+// case would need per-sink rendering, which Record's single shared Msg
+// doesn't support today.
+func ctxPrefix(format Format, fields []Field) string {
+	if format == FormatJSON {
+		return ""
+	}
+	return fieldsPrefix(fields)
+}
+
+// fieldsPrefix renders fields as "[rid=... tid=...] " for text mode, or ""
+// if there are none.
+func fieldsPrefix(fields []Field) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%s=%v", f.Key, f.Value)
+	}
+	b.WriteString("] ")
+	return b.String()
+}
+
+// fieldsToMap renders fields as a Record.Fields map for JSON mode.
+func fieldsToMap(fields []Field) map[string]interface{} {
+	if len(fields) == 0 {
+		return nil
+	}
+	m := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		m[f.Key] = f.Value
+	}
+	return m
+}
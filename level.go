@@ -0,0 +1,18 @@
+package log
+
+// Level controls the minimum severity a Logger will print. Log calls below
+// the configured Level are skipped before their arguments are formatted, so
+// disabled levels don't pay the fmt.Sprint/Sprintf cost.
+type Level int
+
+// Severities, from most to least verbose. New loggers default to
+// LevelInfo, matching the package's historical behavior of printing
+// Info/Warning/Fatal but not Debug.
+const (
+	LevelTrace Level = iota
+	LevelDebug
+	LevelInfo
+	LevelWarning
+	LevelError
+	LevelFatal
+)
@@ -1,7 +1,9 @@
 package log
 
 import (
+	"context"
 	"fmt"
+	"io"
 	golog "log"
 	"os"
 	"sync"
@@ -13,13 +15,23 @@ type Outputer interface {
 }
 
 var (
-	// Verbose triggers printing og debug info
-	Verbose     = false
-	createflags = golog.LstdFlags | golog.Lshortfile
-	Ilog        = CreateMultiplePrint(golog.New(os.Stdout, "I:", createflags))
-	Dlog        = CreateMultiplePrint(golog.New(os.Stdout, "D:", createflags))
-	Wlog        = CreateMultiplePrint(golog.New(os.Stdout, "W:", createflags))
-	Flog        = CreateMultiplePrint(golog.New(os.Stdout, "C:", createflags))
+	// Verbose triggers printing og debug info. It's a compatibility shim
+	// predating Level: setting it true has the same effect on Debug/Debugf
+	// as SetLevel(LevelDebug), without changing std's configured Level.
+	Verbose = false
+
+	// std is the Logger backing the package-level functions below. Library
+	// code that wants an isolated logger (its own prefix, flags or
+	// destination) should call New instead of touching std.
+	std = New(os.Stdout, "", golog.LstdFlags|golog.Lshortfile)
+
+	// Ilog, Dlog, Wlog and Flog expose std's underlying per-level sinks for
+	// callers that appended directly to them before Logger existed. They are
+	// refreshed by SetFlags/Reset/AppendFileWriter/AppendFileDescriptor.
+	Ilog = std.ilog
+	Dlog = std.dlog
+	Wlog = std.wlog
+	Flog = std.flog
 )
 
 // Bits or'ed together to control what's printed.
@@ -38,24 +50,20 @@ const (
 	Llongfile                     // full file name and line number: /a/b/c/d.go:23
 	Lshortfile                    // final file name element and line number: d.go:23. overrides Llongfile
 	LUTC                          // if Ldate or Ltime is set, use UTC rather than the local time zone
+	Lmsgprefix                    // move the "prefix" from the beginning of the line to just before the message
 	LstdFlags     = Ldate | Ltime // initial values for the standard logger
 )
 
 // SetFlags Recreate the outputs with new flags
 func SetFlags(flags int) {
-	createflags = flags
-	Ilog = CreateMultiplePrint(golog.New(os.Stdout, "I:", flags))
-	Dlog = CreateMultiplePrint(golog.New(os.Stdout, "D:", flags))
-	Wlog = CreateMultiplePrint(golog.New(os.Stdout, "W:", flags))
-	Flog = CreateMultiplePrint(golog.New(os.Stdout, "C:", flags))
+	std.SetFlags(flags)
+	Ilog, Dlog, Wlog, Flog = std.ilog, std.dlog, std.wlog, std.flog
 }
 
 // Reset sets all print output streams to zerovalue. Effectivly preventing any output
 func Reset() {
-	Ilog = &MultiplePrint{}
-	Dlog = &MultiplePrint{}
-	Wlog = &MultiplePrint{}
-	Flog = &MultiplePrint{}
+	std.ilog, std.dlog, std.wlog, std.flog = &MultiplePrint{}, &MultiplePrint{}, &MultiplePrint{}, &MultiplePrint{}
+	Ilog, Dlog, Wlog, Flog = std.ilog, std.dlog, std.wlog, std.flog
 }
 
 // AppendFileWriter writes the log to the spesified filename
@@ -64,23 +72,56 @@ func AppendFileWriter(filename string) error {
 	if err != nil {
 		return err
 	}
-	Ilog.Append(golog.New(f, "I:", golog.LstdFlags|golog.Lshortfile))
-	Dlog.Append(golog.New(f, "D:", golog.LstdFlags|golog.Lshortfile))
-	Wlog.Append(golog.New(f, "W:", golog.LstdFlags|golog.Lshortfile))
-	Flog.Append(golog.New(f, "C:", golog.LstdFlags|golog.Lshortfile))
+	std.Append(f)
+	Ilog, Dlog, Wlog, Flog = std.ilog, std.dlog, std.wlog, std.flog
 	return nil
 }
 
 // AppendFileDescriptor writes the log to a specific file descriptor
 func AppendFileDescriptor(f *os.File) {
-	Ilog.Append(golog.New(f, "I:", golog.LstdFlags|golog.Lshortfile))
-	Dlog.Append(golog.New(f, "D:", golog.LstdFlags|golog.Lshortfile))
-	Wlog.Append(golog.New(f, "W:", golog.LstdFlags|golog.Lshortfile))
-	Flog.Append(golog.New(f, "C:", golog.LstdFlags|golog.Lshortfile))
+	std.Append(f)
+	Ilog, Dlog, Wlog, Flog = std.ilog, std.dlog, std.wlog, std.flog
+}
+
+// SetLevel sets the minimum severity printed by Trace/Debug/Info/Warning/
+// Error. Fatal and Panic are always printed regardless of level.
+func SetLevel(level Level) {
+	std.SetLevel(level)
+}
+
+// SetFormat switches std's primary output between FormatText and
+// FormatJSON. Use AppendJSONWriter instead to stack a JSON sink alongside
+// the existing one rather than replacing it.
+func SetFormat(format Format) {
+	std.SetFormat(format)
+	Ilog, Dlog, Wlog, Flog = std.ilog, std.dlog, std.wlog, std.flog
+}
+
+// AppendJSONWriter adds out as an additional JSON-formatted destination
+// for every level, alongside whatever std already writes to.
+func AppendJSONWriter(out io.Writer) {
+	std.AppendJSONWriter(out)
+	Ilog, Dlog, Wlog, Flog = std.ilog, std.dlog, std.wlog, std.flog
 }
 
-// MultiplePrint is an Outputer that supports stacking of multiple outputs
+// MultiplePrint is an Outputer that supports stacking of multiple outputs.
+// Each MultiplePrint (i.e. each of Ilog/Dlog/Wlog/Flog, or each stream of
+// a Logger) has its own mutex, so heavy traffic on one level never blocks
+// another.
+//
+// calldepth contract: Output(calldepth, s) and OutputRecord(calldepth, r)
+// expect calldepth to count the function frames between the call site
+// whose file/line should be reported and this Output call, inclusive of
+// the immediate caller: pass 1 when calling Output directly from the code
+// you want attributed, or one more for every wrapper in between (e.g. the
+// package-level Debug and Println pass 2, since they're themselves one
+// wrapper around it, while Info, Warning, etc. pass 3, since they also go
+// through Logger.logRecord). MultiplePrint passes calldepth+1 on to each
+// Outputer.Output it holds, accounting for the frame MultiplePrint itself
+// adds, so every sink's runtime.Caller lookup lands on the original
+// application call site rather than on MultiplePrint or golog.Logger.
 type MultiplePrint struct {
+	mu   sync.Mutex
 	outs []Outputer
 }
 
@@ -91,79 +132,224 @@ func CreateMultiplePrint(o Outputer) *MultiplePrint {
 
 // Output outputs to all outs
 func (d *MultiplePrint) Output(i int, s string) error {
-	exclusiv.Lock()
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	for _, v := range d.outs {
 		v.Output(i+1, s)
 	}
-	exclusiv.Unlock()
 	return nil
 }
 
 // Append an Outputer to the list
 func (d *MultiplePrint) Append(o Outputer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	d.outs = append(d.outs, o)
 }
 
-var exclusiv sync.Mutex
-
-// Info logging
-func Info(x ...interface{}) {
-	Ilog.Output(2, fmt.Sprint(x...))
+// Trace logging, for detail below Debug. A thin wrapper over std; see
+// Logger.logRecord for why it calls that directly rather than std.Trace.
+func Trace(x ...interface{}) {
+	std.logRecord(std.dlog, LevelTrace, "TRACE", 3, func() (string, map[string]interface{}) { return fmt.Sprint(x...), nil })
 }
 
-// Infof logging
-func Infof(format string, x ...interface{}) {
-	Ilog.Output(2, fmt.Sprintf(format, x...))
+// Tracef logging, for detail below Debug
+func Tracef(format string, x ...interface{}) {
+	std.logRecord(std.dlog, LevelTrace, "TRACE", 3, func() (string, map[string]interface{}) { return fmt.Sprintf(format, x...), nil })
 }
 
-// Debug logging
+// Debug logging. Unlike the rest of the package-level functions, this
+// can't be a plain call into std.logRecord: Verbose is a bare global that
+// predates Level and must force output regardless of std's configured
+// level, which the gate in std.logRecord can't express.
 func Debug(x ...interface{}) {
-	if Verbose {
-		Dlog.Output(2, fmt.Sprint(x...))
+	if !Verbose && std.level > LevelDebug {
+		return
 	}
+	std.dlog.OutputRecord(2, Record{Level: "DEBUG", Msg: fmt.Sprint(x...)})
 }
 
-// Debugf logging
+// Debugf logging. See Debug.
 func Debugf(format string, x ...interface{}) {
-	if Verbose {
-		Dlog.Output(2, fmt.Sprintf(format, x...))
+	if !Verbose && std.level > LevelDebug {
+		return
 	}
+	std.dlog.OutputRecord(2, Record{Level: "DEBUG", Msg: fmt.Sprintf(format, x...)})
+}
+
+// Info logging
+func Info(x ...interface{}) {
+	std.logRecord(std.ilog, LevelInfo, "INFO", 3, func() (string, map[string]interface{}) { return fmt.Sprint(x...), nil })
+}
+
+// Infof logging
+func Infof(format string, x ...interface{}) {
+	std.logRecord(std.ilog, LevelInfo, "INFO", 3, func() (string, map[string]interface{}) { return fmt.Sprintf(format, x...), nil })
 }
 
 // Warning logging
 func Warning(x ...interface{}) {
-	Wlog.Output(2, fmt.Sprint(x...))
+	std.logRecord(std.wlog, LevelWarning, "WARNING", 3, func() (string, map[string]interface{}) { return fmt.Sprint(x...), nil })
 }
 
 // Warningf logging with formatting
 func Warningf(format string, x ...interface{}) {
-	Wlog.Output(2, fmt.Sprintf(format, x...))
+	std.logRecord(std.wlog, LevelWarning, "WARNING", 3, func() (string, map[string]interface{}) { return fmt.Sprintf(format, x...), nil })
+}
+
+// Error logging, on the same stream as Fatal but without exiting
+func Error(x ...interface{}) {
+	std.logRecord(std.flog, LevelError, "ERROR", 3, func() (string, map[string]interface{}) { return fmt.Sprint(x...), nil })
+}
+
+// Errorf logging, on the same stream as Fatal but without exiting
+func Errorf(format string, x ...interface{}) {
+	std.logRecord(std.flog, LevelError, "ERROR", 3, func() (string, map[string]interface{}) { return fmt.Sprintf(format, x...), nil })
+}
+
+// InfoKV logs msg with structured key/value fields. See Logger.InfoKV.
+func InfoKV(msg string, kv ...interface{}) {
+	std.logRecord(std.ilog, LevelInfo, "INFO", 3, func() (string, map[string]interface{}) { return msg, kvToFields(kv) })
+}
+
+// DebugKV logs msg with structured key/value fields. See Logger.InfoKV and Debug.
+func DebugKV(msg string, kv ...interface{}) {
+	if !Verbose && std.level > LevelDebug {
+		return
+	}
+	std.dlog.OutputRecord(2, Record{Level: "DEBUG", Msg: msg, Fields: kvToFields(kv)})
+}
+
+// WarningKV logs msg with structured key/value fields. See Logger.InfoKV.
+func WarningKV(msg string, kv ...interface{}) {
+	std.logRecord(std.wlog, LevelWarning, "WARNING", 3, func() (string, map[string]interface{}) { return msg, kvToFields(kv) })
+}
+
+// ErrorKV logs msg with structured key/value fields. See Logger.InfoKV.
+func ErrorKV(msg string, kv ...interface{}) {
+	std.logRecord(std.flog, LevelError, "ERROR", 3, func() (string, map[string]interface{}) { return msg, kvToFields(kv) })
+}
+
+// InfoCtx logs like Info, prefixed with ctx's request/trace IDs and
+// whatever its registered ContextExtractors find in it. See Logger.InfoCtx.
+func InfoCtx(ctx context.Context, x ...interface{}) {
+	std.logRecord(std.ilog, LevelInfo, "INFO", 3, func() (string, map[string]interface{}) {
+		fields := extractFields(ctx)
+		return ctxPrefix(std.format, fields) + fmt.Sprint(x...), fieldsToMap(fields)
+	})
+}
+
+// InfofCtx logs like InfoCtx, with format/args in place of x.
+func InfofCtx(ctx context.Context, format string, x ...interface{}) {
+	std.logRecord(std.ilog, LevelInfo, "INFO", 3, func() (string, map[string]interface{}) {
+		fields := extractFields(ctx)
+		return ctxPrefix(std.format, fields) + fmt.Sprintf(format, x...), fieldsToMap(fields)
+	})
+}
+
+// DebugCtx logs like Debug. See Logger.InfoCtx and Debug.
+func DebugCtx(ctx context.Context, x ...interface{}) {
+	if !Verbose && std.level > LevelDebug {
+		return
+	}
+	fields := extractFields(ctx)
+	std.dlog.OutputRecord(2, Record{Level: "DEBUG", Msg: ctxPrefix(std.format, fields) + fmt.Sprint(x...), Fields: fieldsToMap(fields)})
+}
+
+// DebugfCtx logs like DebugCtx, with format/args in place of x.
+func DebugfCtx(ctx context.Context, format string, x ...interface{}) {
+	if !Verbose && std.level > LevelDebug {
+		return
+	}
+	fields := extractFields(ctx)
+	std.dlog.OutputRecord(2, Record{Level: "DEBUG", Msg: ctxPrefix(std.format, fields) + fmt.Sprintf(format, x...), Fields: fieldsToMap(fields)})
+}
+
+// WarningCtx logs like Warning. See Logger.InfoCtx.
+func WarningCtx(ctx context.Context, x ...interface{}) {
+	std.logRecord(std.wlog, LevelWarning, "WARNING", 3, func() (string, map[string]interface{}) {
+		fields := extractFields(ctx)
+		return ctxPrefix(std.format, fields) + fmt.Sprint(x...), fieldsToMap(fields)
+	})
+}
+
+// WarningfCtx logs like WarningCtx, with format/args in place of x.
+func WarningfCtx(ctx context.Context, format string, x ...interface{}) {
+	std.logRecord(std.wlog, LevelWarning, "WARNING", 3, func() (string, map[string]interface{}) {
+		fields := extractFields(ctx)
+		return ctxPrefix(std.format, fields) + fmt.Sprintf(format, x...), fieldsToMap(fields)
+	})
+}
+
+// FatalCtx logs like Fatal, with exit. See Logger.InfoCtx.
+func FatalCtx(ctx context.Context, x ...interface{}) {
+	fields := extractFields(ctx)
+	std.logRecord(std.flog, LevelFatal, "FATAL", 3, func() (string, map[string]interface{}) {
+		return ctxPrefix(std.format, fields) + fmt.Sprint(x...), fieldsToMap(fields)
+	})
+	os.Exit(1)
+}
+
+// FatalfCtx logs like FatalCtx, with format/args in place of x, with exit.
+func FatalfCtx(ctx context.Context, format string, x ...interface{}) {
+	fields := extractFields(ctx)
+	std.logRecord(std.flog, LevelFatal, "FATAL", 3, func() (string, map[string]interface{}) {
+		return ctxPrefix(std.format, fields) + fmt.Sprintf(format, x...), fieldsToMap(fields)
+	})
+	os.Exit(1)
 }
 
 // Fatal logging, with exit
 func Fatal(x ...interface{}) {
-	Flog.Output(2, fmt.Sprint(x...))
+	std.logRecord(std.flog, LevelFatal, "FATAL", 3, func() (string, map[string]interface{}) { return fmt.Sprint(x...), nil })
 	os.Exit(1)
 }
 
 // Fatalf logging, with exit
 func Fatalf(format string, x ...interface{}) {
-	Flog.Output(2, fmt.Sprintf(format, x...))
+	std.logRecord(std.flog, LevelFatal, "FATAL", 3, func() (string, map[string]interface{}) { return fmt.Sprintf(format, x...), nil })
 	os.Exit(1)
 }
 
+// Fatalln logging, with exit
+func Fatalln(x ...interface{}) {
+	std.logRecord(std.flog, LevelFatal, "FATAL", 3, func() (string, map[string]interface{}) { return fmt.Sprintln(x...), nil })
+	os.Exit(1)
+}
+
+// Panic logging, followed by a call to panic()
+func Panic(x ...interface{}) {
+	s := fmt.Sprint(x...)
+	std.logRecord(std.flog, LevelFatal, "PANIC", 3, func() (string, map[string]interface{}) { return s, nil })
+	panic(s)
+}
+
+// Panicf logging, followed by a call to panic()
+func Panicf(format string, x ...interface{}) {
+	s := fmt.Sprintf(format, x...)
+	std.logRecord(std.flog, LevelFatal, "PANIC", 3, func() (string, map[string]interface{}) { return s, nil })
+	panic(s)
+}
+
+// Panicln logging, followed by a call to panic()
+func Panicln(x ...interface{}) {
+	s := fmt.Sprintln(x...)
+	std.logRecord(std.flog, LevelFatal, "PANIC", 3, func() (string, map[string]interface{}) { return s, nil })
+	panic(s)
+}
+
 // Println supports original "log" package style
 func Println(x ...interface{}) {
-	Ilog.Output(2, fmt.Sprintln(x...))
+	std.ilog.Output(2, fmt.Sprintln(x...))
 }
 
 // Printf supports original "log" package style
 func Printf(format string, x ...interface{}) {
-	Ilog.Output(2, fmt.Sprintf(format, x...))
+	std.ilog.Output(2, fmt.Sprintf(format, x...))
 }
 
 // PrintfLevel makes it possible to print while refering to
 // code up level above the actual code.
 func PrintfLevel(up int, format string, x ...interface{}) {
-	Ilog.Output(2+up, fmt.Sprintf(format, x...))
+	std.ilog.Output(2+up, fmt.Sprintf(format, x...))
 }
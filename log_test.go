@@ -1,7 +1,19 @@
 package log
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	golog "log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLogger(t *testing.T) {
@@ -12,3 +24,365 @@ func TestLogger(t *testing.T) {
 	Debug("Should be visible")
 	Warning("A warning")
 }
+
+// TestMultiplePrintTwoSinksShowCallerLine is a regression test for a bug
+// where a second appended sink could end up reporting an internal frame
+// (MultiplePrint or golog.Logger) instead of the caller's file/line. Both
+// sinks must agree with each other and with the actual call site.
+func TestMultiplePrintTwoSinksShowCallerLine(t *testing.T) {
+	var bufA, bufB bytes.Buffer
+	mp := CreateMultiplePrint(golog.New(&bufA, "A:", golog.Lshortfile))
+	mp.Append(golog.New(&bufB, "B:", golog.Lshortfile))
+
+	_, _, callerLine, _ := runtime.Caller(0)
+	mp.Output(1, "hello") // callerLine+1
+	callerLine++
+
+	want := "log_test.go:" + strconv.Itoa(callerLine) + ":"
+	for name, buf := range map[string]*bytes.Buffer{"A": &bufA, "B": &bufB} {
+		if out := buf.String(); !strings.Contains(out, want) {
+			t.Errorf("sink %s = %q, want it to contain %q", name, out, want)
+		}
+	}
+}
+
+// TestLoggerJSONRecordLevels is a regression test for a bug where Error and
+// Panic, sharing the flog stream with Fatal, were tagged "FATAL" in
+// FormatJSON output instead of their own level: flog's JSONWriter is
+// constructed once with level fixed to "FATAL", and Error/Panic used to go
+// through the plain Output path that just writes whatever level the sink
+// was built with.
+func TestLoggerJSONRecordLevels(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	l.SetFormat(FormatJSON)
+
+	l.Info("hello")
+	l.Error("oops")
+	func() {
+		defer func() { recover() }()
+		l.Panic("boom")
+	}()
+
+	var records []Record
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var r Record
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("decoding JSON record: %v", err)
+		}
+		records = append(records, r)
+	}
+
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3: %+v", len(records), records)
+	}
+	for i, want := range []struct {
+		level, msg string
+	}{
+		{"INFO", "hello"},
+		{"ERROR", "oops"},
+		{"PANIC", "boom"},
+	} {
+		if records[i].Level != want.level || records[i].Msg != want.msg {
+			t.Errorf("record %d = %+v, want level %q msg %q", i, records[i], want.level, want.msg)
+		}
+		if records[i].File == "" || records[i].Line == 0 {
+			t.Errorf("record %d missing file/line: %+v", i, records[i])
+		}
+	}
+}
+
+// TestInfoCtxTextMode checks that InfoCtx renders context fields as the
+// "[rid=... tid=...] " text prefix, and nothing else.
+func TestInfoCtxTextMode(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+
+	ctx := WithRequestID(WithTraceID(context.Background(), "t1"), "r1")
+	l.InfoCtx(ctx, "hello")
+
+	got := buf.String()
+	if !strings.Contains(got, "[rid=r1 tid=t1] hello") {
+		t.Errorf("InfoCtx text output = %q, want it to contain %q", got, "[rid=r1 tid=t1] hello")
+	}
+}
+
+// TestInfoCtxJSONMode is a regression test for a bug where InfoCtx
+// prepended the text-mode "[rid=... tid=...] " prefix to Msg even in
+// FormatJSON, duplicating the same data that's already attached as JSON
+// fields and defeating the point of structured output.
+func TestInfoCtxJSONMode(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	l.SetFormat(FormatJSON)
+
+	ctx := WithRequestID(context.Background(), "r1")
+	l.InfoCtx(ctx, "hello")
+
+	var r Record
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatalf("decoding JSON record: %v", err)
+	}
+	if r.Msg != "hello" {
+		t.Errorf("Msg = %q, want %q (no text prefix in JSON mode)", r.Msg, "hello")
+	}
+	if r.Fields["rid"] != "r1" {
+		t.Errorf("Fields[%q] = %v, want %q", "rid", r.Fields["rid"], "r1")
+	}
+}
+
+// TestRegisterContextExtractor checks that a registered ContextExtractor's
+// fields show up alongside the built-in rid/tid extraction.
+func TestRegisterContextExtractor(t *testing.T) {
+	old := extractors
+	defer func() { extractors = old }()
+	extractors = nil
+
+	RegisterContextExtractor(func(ctx context.Context) []Field {
+		return []Field{{Key: "user", Value: "alice"}}
+	})
+
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	l.SetFormat(FormatJSON)
+	l.InfoCtx(context.Background(), "hello")
+
+	var r Record
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatalf("decoding JSON record: %v", err)
+	}
+	if r.Fields["user"] != "alice" {
+		t.Errorf("Fields[%q] = %v, want %q", "user", r.Fields["user"], "alice")
+	}
+}
+
+// TestPackageFunctionsReportCallerLine is a regression test for the
+// package-level Info/Debug/... functions, which delegate into std's shared
+// logRecord rather than reimplementing it by hand. That delegation adds a
+// stack frame relative to calling MultiplePrint directly, so it must use
+// one more calldepth than a direct MultiplePrint call (the same calldepth
+// Logger's own methods use); this pins the resulting file/line down to the
+// actual call site.
+func TestPackageFunctionsReportCallerLine(t *testing.T) {
+	var buf bytes.Buffer
+	old := std
+	std = New(&buf, "", 0)
+	std.SetFormat(FormatJSON)
+	defer func() { std = old }()
+
+	_, _, callerLine, _ := runtime.Caller(0)
+	Info("hi")
+	callerLine++
+
+	var r Record
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatalf("decoding JSON record: %v", err)
+	}
+	if r.Level != "INFO" || r.Msg != "hi" {
+		t.Errorf("record = %+v, want level INFO msg \"hi\"", r)
+	}
+	if !strings.HasSuffix(r.File, "log_test.go") || r.Line != callerLine {
+		t.Errorf("record file/line = %s:%d, want .../log_test.go:%d", r.File, r.Line, callerLine)
+	}
+}
+
+// stringerCounter counts how many times its String method is called, to
+// verify a suppressed level skips formatting entirely rather than just
+// discarding the result.
+type stringerCounter struct{ n *int }
+
+func (s stringerCounter) String() string {
+	*s.n++
+	return "formatted"
+}
+
+// TestLevelGatingSkipsFormatting is a regression test ensuring a suppressed
+// level doesn't pay the fmt.Sprint cost: Level's doc comment promises this,
+// but nothing previously verified it.
+func TestLevelGatingSkipsFormatting(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	l.SetLevel(LevelError)
+
+	var calls int
+	l.Info(stringerCounter{&calls})
+	if calls != 0 {
+		t.Errorf("Info at LevelError called String() %d times, want 0", calls)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Info at LevelError wrote %q, want nothing", buf.String())
+	}
+
+	l.Error(stringerCounter{&calls})
+	if calls != 1 {
+		t.Errorf("Error at LevelError called String() %d times, want 1", calls)
+	}
+	if buf.Len() == 0 {
+		t.Error("Error at LevelError wrote nothing, want a line")
+	}
+}
+
+// TestRotatingFileWriterRotatesAndKeepsWriting checks the happy path: a
+// write past MaxSize rotates the old content aside and keeps accepting
+// writes in a fresh file.
+func TestRotatingFileWriterRotatesAndKeepsWriting(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "test.log")
+	w, err := NewRotatingFileWriter(filename, RotateOptions{MaxSize: 4})
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter: %v", err)
+	}
+	defer w.Close()
+
+	if _, err := w.Write([]byte("first")); err != nil {
+		t.Fatalf("first Write: %v", err)
+	}
+	if _, err := w.Write([]byte("second")); err != nil {
+		t.Fatalf("second Write: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	var backups int
+	for _, e := range entries {
+		if e.Name() != "test.log" {
+			backups++
+		}
+	}
+	if backups == 0 {
+		t.Error("want at least one rotated backup file, found none")
+	}
+
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != "second" {
+		t.Errorf("current file = %q, want %q", got, "second")
+	}
+}
+
+// TestRotatingFileWriterCompress checks that Compress gzips the rotated
+// backup and removes the plain copy.
+func TestRotatingFileWriterCompress(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "test.log")
+	w, err := NewRotatingFileWriter(filename, RotateOptions{MaxSize: 1, Compress: true})
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter: %v", err)
+	}
+	defer w.Close()
+
+	if _, err := w.Write([]byte("first")); err != nil {
+		t.Fatalf("first Write: %v", err)
+	}
+	if _, err := w.Write([]byte("second")); err != nil {
+		t.Fatalf("second Write: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	var gz string
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".gz") {
+			gz = e.Name()
+		}
+		if strings.HasPrefix(e.Name(), "test.log.") && !strings.HasSuffix(e.Name(), ".gz") {
+			t.Errorf("found uncompressed backup %q, want it removed", e.Name())
+		}
+	}
+	if gz == "" {
+		t.Fatal("want a .gz backup file, found none")
+	}
+
+	f, err := os.Open(filepath.Join(dir, gz))
+	if err != nil {
+		t.Fatalf("Open %s: %v", gz, err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	defer gr.Close()
+	content, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatalf("reading gz content: %v", err)
+	}
+	if string(content) != "first" {
+		t.Errorf("gz backup content = %q, want %q", content, "first")
+	}
+}
+
+// TestRotatingFileWriterPruneBackups checks that no more than MaxBackups
+// rotated files survive.
+func TestRotatingFileWriterPruneBackups(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "test.log")
+	w, err := NewRotatingFileWriter(filename, RotateOptions{MaxSize: 1, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter: %v", err)
+	}
+	defer w.Close()
+
+	for i := 0; i < 4; i++ {
+		if _, err := w.Write([]byte("x")); err != nil {
+			t.Fatalf("Write %d: %v", i, err)
+		}
+		// The backup name's precision is in nanoseconds, but rotations in
+		// a tight loop can still collide; a brief pause keeps each one
+		// distinct so pruning has three separate candidates to choose from
+		// (the first write fits in MaxSize and doesn't rotate).
+		<-time.After(time.Millisecond)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	var backups int
+	for _, e := range entries {
+		if e.Name() != "test.log" {
+			backups++
+		}
+	}
+	if backups > 2 {
+		t.Errorf("got %d backups, want at most MaxBackups=2", backups)
+	}
+}
+
+// TestRotatingFileWriterReopensAfterRotateFailure is a regression test for
+// a bug where rotate() returned early on a rename failure without
+// reopening the file, silencing the sink for good. Removing the file out
+// from under the writer forces os.Rename to fail; rotate (and therefore
+// Write) must still report that error, but the writer must come back
+// with a fresh, usable file rather than staying closed.
+func TestRotatingFileWriterReopensAfterRotateFailure(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "test.log")
+	w, err := NewRotatingFileWriter(filename, RotateOptions{})
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter: %v", err)
+	}
+	defer w.Close()
+
+	if err := os.Remove(filename); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+
+	if err := w.rotate(); err == nil {
+		t.Fatal("rotate() with the file removed out from under it = nil error, want non-nil")
+	}
+
+	if _, err := os.Stat(filename); err != nil {
+		t.Fatalf("file not reopened after failed rotate: %v", err)
+	}
+	if _, err := w.f.Write([]byte("still alive")); err != nil {
+		t.Errorf("writer unusable after failed rotate: %v", err)
+	}
+}
@@ -0,0 +1,345 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	golog "log"
+	"os"
+	"sync"
+)
+
+// Logger is a leveled logger with its own prefix, flags, output and
+// verbosity, independent of the package-level state. It mirrors stdlib
+// log.Logger but keeps golog's separate Info/Debug/Warning/Fatal streams.
+// The zero value is not usable; create one with New.
+type Logger struct {
+	mu     sync.Mutex
+	out    io.Writer
+	prefix string
+	flags  int
+	level  Level
+	format Format
+
+	ilog *MultiplePrint
+	dlog *MultiplePrint
+	wlog *MultiplePrint
+	flog *MultiplePrint
+}
+
+// New creates a Logger that writes to out. prefix is prepended to the
+// per-level marker ("I:", "D:", "W:", "C:") on every line, and flag
+// controls what's included, using the same bits as SetFlags. The Logger
+// starts at LevelInfo.
+func New(out io.Writer, prefix string, flag int) *Logger {
+	l := &Logger{out: out, prefix: prefix, flags: flag, level: LevelInfo}
+	l.rebuild()
+	return l
+}
+
+// rebuild recreates the four level sinks from the current
+// out/prefix/flags/format. Callers must hold l.mu. Note this discards any
+// sinks added via Append, matching the long-standing behavior of the
+// package-level SetFlags.
+func (l *Logger) rebuild() {
+	if l.format == FormatJSON {
+		l.ilog = CreateMultiplePrint(NewJSONWriter(l.out, "INFO"))
+		l.dlog = CreateMultiplePrint(NewJSONWriter(l.out, "DEBUG"))
+		l.wlog = CreateMultiplePrint(NewJSONWriter(l.out, "WARNING"))
+		l.flog = CreateMultiplePrint(NewJSONWriter(l.out, "FATAL"))
+		return
+	}
+	l.ilog = CreateMultiplePrint(golog.New(l.out, l.prefix+"I:", l.flags))
+	l.dlog = CreateMultiplePrint(golog.New(l.out, l.prefix+"D:", l.flags))
+	l.wlog = CreateMultiplePrint(golog.New(l.out, l.prefix+"W:", l.flags))
+	l.flog = CreateMultiplePrint(golog.New(l.out, l.prefix+"C:", l.flags))
+}
+
+// SetFormat switches this Logger's primary output between FormatText and
+// FormatJSON. Use AppendJSONWriter instead to stack a JSON sink alongside
+// the existing one rather than replacing it.
+func (l *Logger) SetFormat(format Format) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.format = format
+	l.rebuild()
+}
+
+// SetFlags recreates the outputs with new flags.
+func (l *Logger) SetFlags(flag int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flags = flag
+	l.rebuild()
+}
+
+// SetOutput recreates the outputs to write to out instead.
+func (l *Logger) SetOutput(out io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out = out
+	l.rebuild()
+}
+
+// SetPrefix recreates the outputs using prefix as the new base prefix.
+func (l *Logger) SetPrefix(prefix string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prefix = prefix
+	l.rebuild()
+}
+
+// SetLevel sets the minimum severity this Logger prints. Fatal and Panic
+// are always printed regardless of level.
+func (l *Logger) SetLevel(level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+}
+
+// SetVerbose is a compatibility shim for the old single Verbose bool: true
+// maps to LevelDebug, false maps back to LevelInfo.
+func (l *Logger) SetVerbose(verbose bool) {
+	if verbose {
+		l.SetLevel(LevelDebug)
+	} else {
+		l.SetLevel(LevelInfo)
+	}
+}
+
+// Append adds out as an additional destination for every level, using
+// this Logger's current prefix and flags.
+func (l *Logger) Append(out io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ilog.Append(golog.New(out, l.prefix+"I:", l.flags))
+	l.dlog.Append(golog.New(out, l.prefix+"D:", l.flags))
+	l.wlog.Append(golog.New(out, l.prefix+"W:", l.flags))
+	l.flog.Append(golog.New(out, l.prefix+"C:", l.flags))
+}
+
+// AppendJSONWriter adds out as an additional JSON-formatted destination
+// for every level, alongside whatever this Logger already writes to.
+func (l *Logger) AppendJSONWriter(out io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ilog.Append(NewJSONWriter(out, "INFO"))
+	l.dlog.Append(NewJSONWriter(out, "DEBUG"))
+	l.wlog.Append(NewJSONWriter(out, "WARNING"))
+	l.flog.Append(NewJSONWriter(out, "FATAL"))
+}
+
+// Output writes a log event through the Info stream, in the style of
+// stdlib log.Logger.Output. calldepth is the count of stack frames to
+// skip, with 1 identifying the caller of Output.
+func (l *Logger) Output(calldepth int, s string) error {
+	return l.ilog.Output(calldepth+1, s)
+}
+
+// logRecord is the single implementation shared by every Trace/Debug/Info/
+// Warning/Error/Fatal/Panic variant (plain, KV and Ctx alike): it applies
+// the level gate and writes a Record through mp, tagged level. build is
+// only called if the gate passes, so a suppressed call never pays for
+// fmt.Sprint/extractFields/kvToFields, matching Level's documented
+// contract. Passing gate as a level that can never be suppressed
+// (LevelFatal) makes the call unconditional, for Fatal/Panic.
+//
+// It's called directly by both the Logger methods below and the
+// package-level functions in log.go (rather than having the latter call
+// the former) because calldepth is sensitive to the exact number of stack
+// frames between the application's call site and here: both call sites
+// are exactly two frames up (the Logger/package-level function, then
+// logRecord itself), so both pass the same calldepth. Routing package-level
+// calls through the Logger methods would add a third frame and misreport
+// the caller's file/line under Lshortfile/Llongfile.
+func (l *Logger) logRecord(mp *MultiplePrint, gate Level, level string, calldepth int, build func() (string, map[string]interface{})) {
+	if l.level > gate {
+		return
+	}
+	msg, fields := build()
+	mp.OutputRecord(calldepth, Record{Level: level, Msg: msg, Fields: fields})
+}
+
+// Trace logging, for detail below Debug
+func (l *Logger) Trace(x ...interface{}) {
+	l.logRecord(l.dlog, LevelTrace, "TRACE", 3, func() (string, map[string]interface{}) { return fmt.Sprint(x...), nil })
+}
+
+// Tracef logging, for detail below Debug
+func (l *Logger) Tracef(format string, x ...interface{}) {
+	l.logRecord(l.dlog, LevelTrace, "TRACE", 3, func() (string, map[string]interface{}) { return fmt.Sprintf(format, x...), nil })
+}
+
+// Debug logging
+func (l *Logger) Debug(x ...interface{}) {
+	l.logRecord(l.dlog, LevelDebug, "DEBUG", 3, func() (string, map[string]interface{}) { return fmt.Sprint(x...), nil })
+}
+
+// Debugf logging
+func (l *Logger) Debugf(format string, x ...interface{}) {
+	l.logRecord(l.dlog, LevelDebug, "DEBUG", 3, func() (string, map[string]interface{}) { return fmt.Sprintf(format, x...), nil })
+}
+
+// Info logging
+func (l *Logger) Info(x ...interface{}) {
+	l.logRecord(l.ilog, LevelInfo, "INFO", 3, func() (string, map[string]interface{}) { return fmt.Sprint(x...), nil })
+}
+
+// Infof logging
+func (l *Logger) Infof(format string, x ...interface{}) {
+	l.logRecord(l.ilog, LevelInfo, "INFO", 3, func() (string, map[string]interface{}) { return fmt.Sprintf(format, x...), nil })
+}
+
+// Warning logging
+func (l *Logger) Warning(x ...interface{}) {
+	l.logRecord(l.wlog, LevelWarning, "WARNING", 3, func() (string, map[string]interface{}) { return fmt.Sprint(x...), nil })
+}
+
+// Warningf logging with formatting
+func (l *Logger) Warningf(format string, x ...interface{}) {
+	l.logRecord(l.wlog, LevelWarning, "WARNING", 3, func() (string, map[string]interface{}) { return fmt.Sprintf(format, x...), nil })
+}
+
+// Error logging, on the same stream as Fatal but without exiting. Goes
+// through OutputRecord (rather than Output) so FormatJSON sinks tag it
+// "ERROR" instead of inheriting flog's "FATAL" level.
+func (l *Logger) Error(x ...interface{}) {
+	l.logRecord(l.flog, LevelError, "ERROR", 3, func() (string, map[string]interface{}) { return fmt.Sprint(x...), nil })
+}
+
+// Errorf logging, on the same stream as Fatal but without exiting. See Error.
+func (l *Logger) Errorf(format string, x ...interface{}) {
+	l.logRecord(l.flog, LevelError, "ERROR", 3, func() (string, map[string]interface{}) { return fmt.Sprintf(format, x...), nil })
+}
+
+// InfoKV logs msg with structured key/value fields (alternating key,
+// value, key, value...). On a FormatJSON Logger the fields appear as JSON
+// properties; on a FormatText Logger they're only kept if a sink also
+// implements RecordOutputer.
+func (l *Logger) InfoKV(msg string, kv ...interface{}) {
+	l.logRecord(l.ilog, LevelInfo, "INFO", 3, func() (string, map[string]interface{}) { return msg, kvToFields(kv) })
+}
+
+// DebugKV logs msg with structured key/value fields. See InfoKV.
+func (l *Logger) DebugKV(msg string, kv ...interface{}) {
+	l.logRecord(l.dlog, LevelDebug, "DEBUG", 3, func() (string, map[string]interface{}) { return msg, kvToFields(kv) })
+}
+
+// WarningKV logs msg with structured key/value fields. See InfoKV.
+func (l *Logger) WarningKV(msg string, kv ...interface{}) {
+	l.logRecord(l.wlog, LevelWarning, "WARNING", 3, func() (string, map[string]interface{}) { return msg, kvToFields(kv) })
+}
+
+// ErrorKV logs msg with structured key/value fields. See InfoKV.
+func (l *Logger) ErrorKV(msg string, kv ...interface{}) {
+	l.logRecord(l.flog, LevelError, "ERROR", 3, func() (string, map[string]interface{}) { return msg, kvToFields(kv) })
+}
+
+// InfoCtx logs like Info, prefixed in text mode (or attached as fields in
+// JSON mode) with the request/trace IDs set by WithRequestID/WithTraceID
+// and whatever registered ContextExtractors find in ctx.
+func (l *Logger) InfoCtx(ctx context.Context, x ...interface{}) {
+	l.logRecord(l.ilog, LevelInfo, "INFO", 3, func() (string, map[string]interface{}) {
+		fields := extractFields(ctx)
+		return ctxPrefix(l.format, fields) + fmt.Sprint(x...), fieldsToMap(fields)
+	})
+}
+
+// InfofCtx logs like InfoCtx, with format/args in place of x. See InfoCtx.
+func (l *Logger) InfofCtx(ctx context.Context, format string, x ...interface{}) {
+	l.logRecord(l.ilog, LevelInfo, "INFO", 3, func() (string, map[string]interface{}) {
+		fields := extractFields(ctx)
+		return ctxPrefix(l.format, fields) + fmt.Sprintf(format, x...), fieldsToMap(fields)
+	})
+}
+
+// DebugCtx logs like Debug. See InfoCtx.
+func (l *Logger) DebugCtx(ctx context.Context, x ...interface{}) {
+	l.logRecord(l.dlog, LevelDebug, "DEBUG", 3, func() (string, map[string]interface{}) {
+		fields := extractFields(ctx)
+		return ctxPrefix(l.format, fields) + fmt.Sprint(x...), fieldsToMap(fields)
+	})
+}
+
+// DebugfCtx logs like DebugCtx, with format/args in place of x. See InfoCtx.
+func (l *Logger) DebugfCtx(ctx context.Context, format string, x ...interface{}) {
+	l.logRecord(l.dlog, LevelDebug, "DEBUG", 3, func() (string, map[string]interface{}) {
+		fields := extractFields(ctx)
+		return ctxPrefix(l.format, fields) + fmt.Sprintf(format, x...), fieldsToMap(fields)
+	})
+}
+
+// WarningCtx logs like Warning. See InfoCtx.
+func (l *Logger) WarningCtx(ctx context.Context, x ...interface{}) {
+	l.logRecord(l.wlog, LevelWarning, "WARNING", 3, func() (string, map[string]interface{}) {
+		fields := extractFields(ctx)
+		return ctxPrefix(l.format, fields) + fmt.Sprint(x...), fieldsToMap(fields)
+	})
+}
+
+// WarningfCtx logs like WarningCtx, with format/args in place of x. See InfoCtx.
+func (l *Logger) WarningfCtx(ctx context.Context, format string, x ...interface{}) {
+	l.logRecord(l.wlog, LevelWarning, "WARNING", 3, func() (string, map[string]interface{}) {
+		fields := extractFields(ctx)
+		return ctxPrefix(l.format, fields) + fmt.Sprintf(format, x...), fieldsToMap(fields)
+	})
+}
+
+// FatalCtx logs like Fatal, with exit. See InfoCtx.
+func (l *Logger) FatalCtx(ctx context.Context, x ...interface{}) {
+	fields := extractFields(ctx)
+	l.logRecord(l.flog, LevelFatal, "FATAL", 3, func() (string, map[string]interface{}) {
+		return ctxPrefix(l.format, fields) + fmt.Sprint(x...), fieldsToMap(fields)
+	})
+	os.Exit(1)
+}
+
+// FatalfCtx logs like FatalCtx, with format/args in place of x, with exit. See InfoCtx.
+func (l *Logger) FatalfCtx(ctx context.Context, format string, x ...interface{}) {
+	fields := extractFields(ctx)
+	l.logRecord(l.flog, LevelFatal, "FATAL", 3, func() (string, map[string]interface{}) {
+		return ctxPrefix(l.format, fields) + fmt.Sprintf(format, x...), fieldsToMap(fields)
+	})
+	os.Exit(1)
+}
+
+// Fatal logging, with exit
+func (l *Logger) Fatal(x ...interface{}) {
+	l.logRecord(l.flog, LevelFatal, "FATAL", 3, func() (string, map[string]interface{}) { return fmt.Sprint(x...), nil })
+	os.Exit(1)
+}
+
+// Fatalf logging, with exit
+func (l *Logger) Fatalf(format string, x ...interface{}) {
+	l.logRecord(l.flog, LevelFatal, "FATAL", 3, func() (string, map[string]interface{}) { return fmt.Sprintf(format, x...), nil })
+	os.Exit(1)
+}
+
+// Fatalln logging, with exit
+func (l *Logger) Fatalln(x ...interface{}) {
+	l.logRecord(l.flog, LevelFatal, "FATAL", 3, func() (string, map[string]interface{}) { return fmt.Sprintln(x...), nil })
+	os.Exit(1)
+}
+
+// Panic logging, followed by a call to panic(). Goes through OutputRecord
+// (rather than Output) so FormatJSON sinks tag it "PANIC" instead of
+// inheriting flog's "FATAL" level.
+func (l *Logger) Panic(x ...interface{}) {
+	s := fmt.Sprint(x...)
+	l.logRecord(l.flog, LevelFatal, "PANIC", 3, func() (string, map[string]interface{}) { return s, nil })
+	panic(s)
+}
+
+// Panicf logging, followed by a call to panic(). See Panic.
+func (l *Logger) Panicf(format string, x ...interface{}) {
+	s := fmt.Sprintf(format, x...)
+	l.logRecord(l.flog, LevelFatal, "PANIC", 3, func() (string, map[string]interface{}) { return s, nil })
+	panic(s)
+}
+
+// Panicln logging, followed by a call to panic(). See Panic.
+func (l *Logger) Panicln(x ...interface{}) {
+	s := fmt.Sprintln(x...)
+	l.logRecord(l.flog, LevelFatal, "PANIC", 3, func() (string, map[string]interface{}) { return s, nil })
+	panic(s)
+}
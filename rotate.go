@@ -0,0 +1,204 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotateOptions configures a RotatingFileWriter. Zero values disable the
+// corresponding check: MaxSize 0 means no size-based rotation, MaxAge 0
+// means no age-based rotation, MaxBackups 0 means keep every backup.
+type RotateOptions struct {
+	MaxSize    int64
+	MaxAge     time.Duration
+	MaxBackups int
+	Compress   bool
+}
+
+// RotatingFileWriter is an io.Writer over filename that rotates it once
+// it exceeds RotateOptions.MaxSize or RotateOptions.MaxAge, renaming the
+// old file aside (optionally gzip-compressing it) and pruning backups
+// beyond RotateOptions.MaxBackups. Writes are serialized by its own
+// mutex, which composes safely with the per-stream lock MultiplePrint.Output
+// already holds around each call, so a rotation's rename never interleaves
+// with a concurrent write.
+type RotatingFileWriter struct {
+	mu       sync.Mutex
+	filename string
+	opts     RotateOptions
+
+	f        *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// NewRotatingFileWriter opens (or creates) filename and returns a writer
+// that rotates it according to opts.
+func NewRotatingFileWriter(filename string, opts RotateOptions) (*RotatingFileWriter, error) {
+	w := &RotatingFileWriter{filename: filename, opts: opts}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingFileWriter) open() error {
+	f, err := os.OpenFile(w.filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.f = f
+	w.size = info.Size()
+	w.openedAt = info.ModTime()
+	return nil
+}
+
+// Write implements io.Writer, rotating first if p would push the file
+// past MaxSize or the file is already older than MaxAge.
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.shouldRotate(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *RotatingFileWriter) shouldRotate(next int) bool {
+	if w.opts.MaxSize > 0 && w.size+int64(next) > w.opts.MaxSize {
+		return true
+	}
+	if w.opts.MaxAge > 0 && time.Since(w.openedAt) > w.opts.MaxAge {
+		return true
+	}
+	return false
+}
+
+// rotate closes the current file, renames it aside (optionally
+// compressing it) and reopens w.filename, pruning old backups last. Any
+// failure after the Close still must leave w with a usable, open file:
+// otherwise a transient rename/compress error would silence this sink
+// permanently, since every later Write would hit the closed file. So
+// every step past the Close falls through to a reopen attempt instead of
+// returning early.
+func (w *RotatingFileWriter) rotate() error {
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	backup := fmt.Sprintf("%s.%s", w.filename, time.Now().Format("20060102T150405.000000000"))
+	renameErr := os.Rename(w.filename, backup)
+
+	var compressErr error
+	if renameErr == nil && w.opts.Compress {
+		compressErr = compressAndRemove(backup)
+	}
+
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	if renameErr != nil {
+		return renameErr
+	}
+	if compressErr != nil {
+		return compressErr
+	}
+	return w.pruneBackups()
+}
+
+func compressAndRemove(name string) error {
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(name + ".gz")
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		gz.Close()
+		out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
+
+// pruneBackups removes the oldest rotated files once there are more than
+// opts.MaxBackups, relying on the timestamp suffix sorting chronologically.
+func (w *RotatingFileWriter) pruneBackups() error {
+	if w.opts.MaxBackups <= 0 {
+		return nil
+	}
+	dir := filepath.Dir(w.filename)
+	base := filepath.Base(w.filename)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var backups []string
+	for _, e := range entries {
+		name := e.Name()
+		if name != base && strings.HasPrefix(name, base+".") {
+			backups = append(backups, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(backups)
+	for len(backups) > w.opts.MaxBackups {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// Close closes the underlying file.
+func (w *RotatingFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.f.Close()
+}
+
+// AppendRotatingFile wires a RotatingFileWriter for filename into this
+// Logger's Info/Debug/Warning/Fatal streams, rotating it according to opts.
+func (l *Logger) AppendRotatingFile(filename string, opts RotateOptions) error {
+	w, err := NewRotatingFileWriter(filename, opts)
+	if err != nil {
+		return err
+	}
+	l.Append(w)
+	return nil
+}
+
+// AppendRotatingFile wires a RotatingFileWriter for filename into
+// Ilog/Dlog/Wlog/Flog, rotating it according to opts.
+func AppendRotatingFile(filename string, opts RotateOptions) error {
+	if err := std.AppendRotatingFile(filename, opts); err != nil {
+		return err
+	}
+	Ilog, Dlog, Wlog, Flog = std.ilog, std.dlog, std.wlog, std.flog
+	return nil
+}